@@ -0,0 +1,230 @@
+package consumer
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/logger"
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/proxyerror"
+
+	"github.com/Shopify/sarama"
+)
+
+// Config wraps configuration for consumer
+type Config struct {
+	// ErrHandler, when set, receives every consume error and Errors()
+	// returns a nil channel instead, so callers don't have to also
+	// drain it
+	ErrHandler   func(error)
+	KafkaBrokers []string
+	Topics       []string
+	// InitialOffset is where to start reading from a partition that
+	// doesn't yet have a committed offset (sarama.OffsetNewest/OffsetOldest).
+	// Defaults to sarama.OffsetNewest when left 0
+	InitialOffset int64
+	// Allow overwriting default sarama-config
+	SaramaConfig *sarama.Config
+
+	// Logger receives internal log events. Defaults to logger.NoopLogger
+	Logger logger.Logger
+}
+
+// Consumer wraps sarama's Consumer, merging all the per-partition
+// consumers for the configured topics into a single Messages/Errors pair
+type Consumer struct {
+	consumer           sarama.Consumer
+	partitionConsumers []sarama.PartitionConsumer
+	messagesChan       chan *sarama.ConsumerMessage
+	errorsChan         chan error
+	logger             logger.Logger
+
+	closeOnce    sync.Once
+	closeErrChan chan error
+
+	mu       sync.Mutex
+	isClosed bool
+}
+
+// New returns a configured sarama Kafka-Consumer instance, subscribed to
+// all partitions of the configured topics
+func New(initConfig *Config) (*Consumer, error) {
+	if initConfig.KafkaBrokers == nil || len(initConfig.KafkaBrokers) == 0 {
+		return nil, proxyerror.BrokersNotSetError("No Kafka Brokers set.")
+	}
+
+	var config *sarama.Config
+	if initConfig.SaramaConfig != nil {
+		config = initConfig.SaramaConfig
+	} else {
+		config = sarama.NewConfig()
+		config.Consumer.Return.Errors = true
+	}
+
+	initialOffset := initConfig.InitialOffset
+	if initialOffset == 0 {
+		initialOffset = sarama.OffsetNewest
+	}
+
+	var proxyLogger logger.Logger = logger.NoopLogger{}
+	if initConfig.Logger != nil {
+		proxyLogger = initConfig.Logger
+	}
+
+	saramaConsumer, err := sarama.NewConsumer(initConfig.KafkaBrokers, config)
+	if err != nil {
+		return nil, proxyerror.ConnectionError(err.Error())
+	}
+
+	proxyConsumer := &Consumer{
+		consumer:     saramaConsumer,
+		messagesChan: make(chan *sarama.ConsumerMessage),
+		logger:       proxyLogger,
+	}
+	// Only create the Errors() channel when nothing else is draining
+	// errors, since an unbuffered channel with no reader would otherwise
+	// wedge handleErrors forever
+	if initConfig.ErrHandler == nil {
+		proxyConsumer.errorsChan = make(chan error)
+	}
+
+	for _, topic := range initConfig.Topics {
+		partitions, err := saramaConsumer.Partitions(topic)
+		if err != nil {
+			proxyConsumer.closePartial()
+			return nil, proxyerror.ConnectionError(err.Error())
+		}
+
+		for _, partition := range partitions {
+			partitionConsumer, err := saramaConsumer.ConsumePartition(topic, partition, initialOffset)
+			if err != nil {
+				proxyConsumer.closePartial()
+				return nil, proxyerror.ConnectionError(err.Error())
+			}
+			proxyConsumer.partitionConsumers = append(proxyConsumer.partitionConsumers, partitionConsumer)
+			proxyConsumer.consumePartition(partitionConsumer)
+		}
+	}
+
+	proxyConsumer.handleKeyInterrupt()
+	proxyConsumer.handleErrors(initConfig.ErrHandler)
+	return proxyConsumer, nil
+}
+
+// closePartial closes whatever partition-consumers and consumer were
+// already opened before a later step in New failed, so a returned error
+// never leaks open broker connections
+func (c *Consumer) closePartial() {
+	for _, partitionConsumer := range c.partitionConsumers {
+		if err := partitionConsumer.Close(); err != nil {
+			c.logger.Error("Error closing partition-consumer during partial setup failure.", err)
+		}
+	}
+	if err := c.consumer.Close(); err != nil {
+		c.logger.Error("Error closing consumer during partial setup failure.", err)
+	}
+}
+
+// EnableLogging logs events to console
+func (c *Consumer) EnableLogging() {
+	c.logger = logger.StdLogger{}
+}
+
+// IsClosed returns a bool specifying if Kafka consumer is closed
+func (c *Consumer) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isClosed
+}
+
+// Messages returns the merged message-channel for all subscribed partitions
+func (c *Consumer) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messagesChan
+}
+
+// Errors returns the merged error-channel for all subscribed partitions.
+// Only populated when no Config.ErrHandler is configured; nil otherwise
+func (c *Consumer) Errors() <-chan error {
+	return c.errorsChan
+}
+
+func (c *Consumer) consumePartition(partitionConsumer sarama.PartitionConsumer) {
+	go func() {
+		for msg := range partitionConsumer.Messages() {
+			c.messagesChan <- msg
+		}
+	}()
+}
+
+func (c *Consumer) handleErrors(errHandler func(error)) {
+	for _, partitionConsumer := range c.partitionConsumers {
+		partitionConsumer := partitionConsumer
+		go func() {
+			for err := range partitionConsumer.Errors() {
+				c.logger.Error("Error consuming message.", err)
+				// Forward to exactly one consumer: the handler if
+				// configured, otherwise the channel. Feeding both would
+				// still risk a blocked/forgotten channel read wedging
+				// this loop
+				if errHandler != nil {
+					errHandler(err)
+					continue
+				}
+				c.errorsChan <- err
+			}
+		}()
+	}
+}
+
+func (c *Consumer) handleKeyInterrupt() {
+	// Capture the Ctrl+C signal (interrupt or kill)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+
+	// Elegant exit
+	go func() {
+		<-sigChan
+		// Logged through the configured Logger (discarded by the default
+		// NoopLogger)
+		c.logger.Info("Keyboard-Interrupt signal received. Shutting down consumer.")
+		if err := <-c.Close(); err != nil {
+			c.logger.Error("Error closing consumer on interrupt.", err)
+		}
+	}()
+}
+
+// Close attempts to close all partition-consumers and the underlying
+// consumer, and returns a buffered error-channel carrying any errors that
+// occurred while closing. The channel is always closed once shutdown
+// completes. Close is idempotent: repeated calls return the same channel
+func (c *Consumer) Close() chan error {
+	c.closeOnce.Do(func() {
+		c.closeErrChan = make(chan error, len(c.partitionConsumers)+1)
+		go func() {
+			defer close(c.closeErrChan)
+
+			for _, partitionConsumer := range c.partitionConsumers {
+				if err := partitionConsumer.Close(); err != nil {
+					c.logger.Error("Error closing partition-consumer.", err)
+					c.closeErrChan <- err
+				}
+			}
+
+			if err := c.consumer.Close(); err != nil {
+				c.logger.Error("Error closing consumer.", err)
+				c.closeErrChan <- err
+			}
+
+			c.logger.Info("Consumer closed.")
+			c.mu.Lock()
+			c.isClosed = true
+			c.mu.Unlock()
+		}()
+	})
+
+	return c.closeErrChan
+}
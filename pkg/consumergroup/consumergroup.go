@@ -0,0 +1,248 @@
+package consumergroup
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/logger"
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/proxyerror"
+
+	"github.com/Shopify/sarama"
+)
+
+// Handler mirrors sarama.ConsumerGroupHandler: it has the same method set,
+// so any sarama.ConsumerGroupHandler can be passed as a Handler
+type Handler interface {
+	Setup(sarama.ConsumerGroupSession) error
+	Cleanup(sarama.ConsumerGroupSession) error
+	ConsumeClaim(sarama.ConsumerGroupSession, sarama.ConsumerGroupClaim) error
+}
+
+// Config wraps configuration for consumer-group
+type Config struct {
+	// ErrHandler, when set, receives every consume error and Errors()
+	// returns a nil channel instead, so callers don't have to also
+	// drain it
+	ErrHandler   func(error)
+	KafkaBrokers []string
+	Topics       []string
+	GroupID      string
+	// InitialOffset is where to start reading from a partition that
+	// doesn't yet have a committed offset (sarama.OffsetNewest/OffsetOldest).
+	// Defaults to sarama.OffsetNewest when left 0
+	InitialOffset int64
+	// Allow overwriting default sarama-config
+	SaramaConfig *sarama.Config
+
+	// Handler processes claimed messages. If left nil, a default Handler
+	// is used that invokes MessageHandler for every message and
+	// auto-marks it as consumed
+	Handler Handler
+	// MessageHandler is used by the default Handler (ignored if Handler
+	// is set)
+	MessageHandler func(*sarama.ConsumerMessage) error
+
+	// Logger receives internal log events. Defaults to logger.NoopLogger
+	Logger logger.Logger
+}
+
+// ConsumerGroup wraps sarama's ConsumerGroup
+type ConsumerGroup struct {
+	group      sarama.ConsumerGroup
+	handler    Handler
+	topics     []string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	errorsChan chan error
+	errHandler func(error)
+	logger     logger.Logger
+
+	closeOnce    sync.Once
+	closeErrChan chan error
+
+	mu       sync.Mutex
+	isClosed bool
+}
+
+// New returns a configured sarama Kafka-ConsumerGroup instance, and
+// starts consuming the configured topics in the background
+func New(initConfig *Config) (*ConsumerGroup, error) {
+	if initConfig.KafkaBrokers == nil || len(initConfig.KafkaBrokers) == 0 {
+		return nil, proxyerror.BrokersNotSetError("No Kafka Brokers set.")
+	}
+
+	var config *sarama.Config
+	if initConfig.SaramaConfig != nil {
+		config = initConfig.SaramaConfig
+	} else {
+		config = sarama.NewConfig()
+		config.Consumer.Return.Errors = true
+	}
+
+	if initConfig.InitialOffset != 0 {
+		config.Consumer.Offsets.Initial = initConfig.InitialOffset
+	} else if config.Consumer.Offsets.Initial == 0 {
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	}
+
+	group, err := sarama.NewConsumerGroup(initConfig.KafkaBrokers, initConfig.GroupID, config)
+	if err != nil {
+		return nil, proxyerror.ConnectionError(err.Error())
+	}
+
+	handler := initConfig.Handler
+	if handler == nil {
+		handler = &defaultHandler{messageHandler: initConfig.MessageHandler}
+	}
+
+	var proxyLogger logger.Logger = logger.NoopLogger{}
+	if initConfig.Logger != nil {
+		proxyLogger = initConfig.Logger
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	proxyGroup := &ConsumerGroup{
+		group:      group,
+		handler:    handler,
+		topics:     initConfig.Topics,
+		ctx:        ctx,
+		cancel:     cancel,
+		errHandler: initConfig.ErrHandler,
+		logger:     proxyLogger,
+	}
+	// Only create the Errors() channel when nothing else is draining
+	// errors, since an unbuffered channel with no reader would otherwise
+	// wedge consume()'s rebalance loop (and handleErrors) forever
+	if initConfig.ErrHandler == nil {
+		proxyGroup.errorsChan = make(chan error)
+	}
+
+	proxyGroup.consume()
+	proxyGroup.handleErrors()
+	proxyGroup.handleKeyInterrupt()
+	return proxyGroup, nil
+}
+
+// EnableLogging logs events to console
+func (c *ConsumerGroup) EnableLogging() {
+	c.logger = logger.StdLogger{}
+}
+
+// IsClosed returns a bool specifying if Kafka consumer-group is closed
+func (c *ConsumerGroup) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isClosed
+}
+
+// Errors returns the consumer-group's error-channel. Only populated when
+// no Config.ErrHandler is configured; nil otherwise
+func (c *ConsumerGroup) Errors() <-chan error {
+	return c.errorsChan
+}
+
+// forwardError delivers to exactly one consumer: errHandler if
+// configured, otherwise the channel. Feeding both would still risk a
+// blocked/forgotten channel read wedging the caller
+func (c *ConsumerGroup) forwardError(err error) {
+	if c.errHandler != nil {
+		c.errHandler(err)
+		return
+	}
+	c.errorsChan <- err
+}
+
+// consume runs group.Consume in a loop, since it returns whenever a
+// rebalance happens and must be re-invoked to keep consuming
+func (c *ConsumerGroup) consume() {
+	go func() {
+		for {
+			if err := c.group.Consume(c.ctx, c.topics, c.handler); err != nil {
+				c.logger.Error("Error consuming from consumer-group.", err)
+				c.forwardError(err)
+			}
+			if c.ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (c *ConsumerGroup) handleErrors() {
+	go func() {
+		for err := range c.group.Errors() {
+			c.logger.Error("Consumer-group error.", err)
+			c.forwardError(err)
+		}
+	}()
+}
+
+func (c *ConsumerGroup) handleKeyInterrupt() {
+	// Capture the Ctrl+C signal (interrupt or kill)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+
+	// Elegant exit
+	go func() {
+		<-sigChan
+		// Logged through the configured Logger (discarded by the default
+		// NoopLogger)
+		c.logger.Info("Keyboard-Interrupt signal received. Shutting down consumer-group.")
+		if err := <-c.Close(); err != nil {
+			c.logger.Error("Error closing consumer-group on interrupt.", err)
+		}
+	}()
+}
+
+// Close attempts to close the consumer-group, and returns a buffered
+// error-channel carrying any errors that occurred while closing. The
+// channel is always closed once shutdown completes. Close is idempotent:
+// repeated calls return the same channel
+func (c *ConsumerGroup) Close() chan error {
+	c.closeOnce.Do(func() {
+		c.closeErrChan = make(chan error, 1)
+		go func() {
+			defer close(c.closeErrChan)
+
+			c.cancel()
+			if err := c.group.Close(); err != nil {
+				c.logger.Error("Error closing consumer-group.", err)
+				c.closeErrChan <- err
+			}
+
+			c.logger.Info("Consumer-group closed.")
+			c.mu.Lock()
+			c.isClosed = true
+			c.mu.Unlock()
+		}()
+	})
+
+	return c.closeErrChan
+}
+
+// defaultHandler forwards claimed messages to a user callback, and
+// auto-marks each message as consumed
+type defaultHandler struct {
+	messageHandler func(*sarama.ConsumerMessage) error
+}
+
+func (h *defaultHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *defaultHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *defaultHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if h.messageHandler != nil {
+			if err := h.messageHandler(msg); err != nil {
+				return err
+			}
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
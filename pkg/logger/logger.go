@@ -0,0 +1,52 @@
+package logger
+
+import "log"
+
+// Logger is the pluggable logging interface used across the proxy
+// packages (producer/consumer/consumergroup), so embedding applications
+// can route log output through their own logging stack
+type Logger interface {
+	Debug(args ...interface{})
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+}
+
+// NoopLogger discards all log output. It's the default Logger when none
+// is configured
+type NoopLogger struct{}
+
+// Debug discards the message
+func (NoopLogger) Debug(args ...interface{}) {}
+
+// Info discards the message
+func (NoopLogger) Info(args ...interface{}) {}
+
+// Warn discards the message
+func (NoopLogger) Warn(args ...interface{}) {}
+
+// Error discards the message
+func (NoopLogger) Error(args ...interface{}) {}
+
+// StdLogger logs via the standard-library log package
+type StdLogger struct{}
+
+// Debug logs the message with a DEBUG prefix
+func (StdLogger) Debug(args ...interface{}) {
+	log.Println(append([]interface{}{"DEBUG:"}, args...)...)
+}
+
+// Info logs the message with an INFO prefix
+func (StdLogger) Info(args ...interface{}) {
+	log.Println(append([]interface{}{"INFO:"}, args...)...)
+}
+
+// Warn logs the message with a WARN prefix
+func (StdLogger) Warn(args ...interface{}) {
+	log.Println(append([]interface{}{"WARN:"}, args...)...)
+}
+
+// Error logs the message with an ERROR prefix
+func (StdLogger) Error(args ...interface{}) {
+	log.Println(append([]interface{}{"ERROR:"}, args...)...)
+}
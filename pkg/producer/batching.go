@@ -0,0 +1,24 @@
+package producer
+
+import "github.com/Shopify/sarama"
+
+// applyBatchingConfig maps the high-level batching knobs on Config onto
+// the underlying sarama-config. Zero values are left untouched so the
+// defaults picked earlier in New (or a caller-supplied SaramaConfig)
+// still apply. RequiredAcks is the one exception: sarama.NoResponse is
+// a legitimate, commonly-used value that is also Go's zero value, so it
+// needs an explicit "is set" pointer rather than a zero-value check
+func applyBatchingConfig(config *sarama.Config, initConfig *Config) {
+	if initConfig.FlushFrequency != 0 {
+		config.Producer.Flush.Frequency = initConfig.FlushFrequency
+	}
+	if initConfig.FlushMaxMessages != 0 {
+		config.Producer.Flush.MaxMessages = initConfig.FlushMaxMessages
+	}
+	if initConfig.CompressionCodec != 0 {
+		config.Producer.Compression = initConfig.CompressionCodec
+	}
+	if initConfig.RequiredAcks != nil {
+		config.Producer.RequiredAcks = *initConfig.RequiredAcks
+	}
+}
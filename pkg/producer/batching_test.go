@@ -0,0 +1,59 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func TestApplyBatchingConfigMapsFields(t *testing.T) {
+	requiredAcks := sarama.NoResponse
+	initConfig := &Config{
+		FlushFrequency:   5 * time.Second,
+		FlushMaxMessages: 100,
+		CompressionCodec: sarama.CompressionGZIP,
+		RequiredAcks:     &requiredAcks,
+	}
+
+	config := sarama.NewConfig()
+	applyBatchingConfig(config, initConfig)
+
+	if config.Producer.Flush.Frequency != 5*time.Second {
+		t.Errorf("expected Flush.Frequency to be mapped, got %v", config.Producer.Flush.Frequency)
+	}
+	if config.Producer.Flush.MaxMessages != 100 {
+		t.Errorf("expected Flush.MaxMessages to be mapped, got %d", config.Producer.Flush.MaxMessages)
+	}
+	if config.Producer.Compression != sarama.CompressionGZIP {
+		t.Errorf("expected Compression to be mapped, got %v", config.Producer.Compression)
+	}
+	if config.Producer.RequiredAcks != sarama.NoResponse {
+		t.Errorf("expected RequiredAcks to be mapped to NoResponse, got %v", config.Producer.RequiredAcks)
+	}
+}
+
+func TestApplyBatchingConfigLeavesDefaultsWhenUnset(t *testing.T) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	defaultFrequency := config.Producer.Flush.Frequency
+	defaultMaxMessages := config.Producer.Flush.MaxMessages
+	defaultCompression := config.Producer.Compression
+
+	applyBatchingConfig(config, &Config{})
+
+	if config.Producer.Flush.Frequency != defaultFrequency {
+		t.Errorf("expected Flush.Frequency to be left untouched, got %v", config.Producer.Flush.Frequency)
+	}
+	if config.Producer.Flush.MaxMessages != defaultMaxMessages {
+		t.Errorf("expected Flush.MaxMessages to be left untouched, got %d", config.Producer.Flush.MaxMessages)
+	}
+	if config.Producer.Compression != defaultCompression {
+		t.Errorf("expected Compression to be left untouched, got %v", config.Producer.Compression)
+	}
+	// RequiredAcks is nil (unset) in initConfig, so it must not override
+	// the WaitForAll already on config, even though NoResponse is zero
+	if config.Producer.RequiredAcks != sarama.WaitForAll {
+		t.Errorf("expected RequiredAcks to be left untouched, got %v", config.Producer.RequiredAcks)
+	}
+}
@@ -1,11 +1,13 @@
 package producer
 
 import (
-	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/logger"
 	"github.com/Jaskaranbir/go-kafkaproxy/pkg/proxyerror"
 
 	"github.com/Shopify/sarama"
@@ -20,22 +22,98 @@ type Adapter interface {
 	Errors() <-chan *sarama.ProducerError
 }
 
+// Mode specifies whether Producer wraps sarama's AsyncProducer or
+// SyncProducer
+type Mode int
+
+const (
+	// ModeAsync wraps sarama.AsyncProducer (default)
+	ModeAsync Mode = iota
+	// ModeSync wraps sarama.SyncProducer
+	ModeSync
+)
+
 // Config wraps configuration for producer
 type Config struct {
 	ErrHandler   func(*sarama.ProducerError)
 	KafkaBrokers []string
 	// Allow overwriting default sarama-config
 	SaramaConfig *sarama.Config
+
+	// Mode selects between ModeAsync (default) and ModeSync
+	Mode Mode
+
+	// TLSEnable turns on TLS for the Kafka connection
+	TLSEnable bool
+	// TLSCertFile/TLSKeyFile are paths to a PEM-encoded client
+	// certificate/key pair, used when the broker requires client-auth
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSCAFile is the path to a PEM-encoded CA bundle used to verify
+	// the broker's certificate
+	TLSCAFile string
+	// TLSInsecureSkipVerify disables broker certificate verification
+	TLSInsecureSkipVerify bool
+
+	// SASLEnable turns on SASL authentication for the Kafka connection
+	SASLEnable bool
+	// SASLMechanism selects the SASL mechanism (defaults to sarama's
+	// PLAIN mechanism when left empty)
+	SASLMechanism sarama.SASLMechanism
+	SASLUser      string
+	SASLPassword  string
+
+	// FlushFrequency/FlushMaxMessages tune how aggressively messages are
+	// batched before being sent (config.Producer.Flush.*)
+	FlushFrequency   time.Duration
+	FlushMaxMessages int
+	// CompressionCodec overrides the producer's compression codec
+	CompressionCodec sarama.CompressionCodec
+	// RequiredAcks overrides the producer's required-acks level. A
+	// pointer, since sarama.NoResponse (fire-and-forget) is both a
+	// legitimate setting and Go's zero value
+	RequiredAcks *sarama.RequiredAcks
+
+	// SuccessHandler is invoked for every message acknowledged by Kafka,
+	// when SaramaConfig.Producer.Return.Successes is enabled. Only used in
+	// ModeAsync; a ModeSync Producer never calls it. When set,
+	// successes are delivered only to SuccessHandler and Successes()
+	// returns a nil channel, so callers don't have to also drain it
+	SuccessHandler func(*sarama.ProducerMessage)
+
+	// Logger receives internal log events. Defaults to logger.NoopLogger
+	Logger logger.Logger
 }
 
 // Producer wraps sarama's producer
 type Producer struct {
-	producer         Adapter
-	isClosed         bool
-	isLoggingEnabled bool
+	client        sarama.Client
+	producer      Adapter
+	syncProducer  sarama.SyncProducer
+	successesChan chan *sarama.ProducerMessage
+	mode          Mode
+	logger        logger.Logger
+
+	closeOnce    sync.Once
+	closeErrChan chan error
+
+	mu       sync.Mutex
+	isClosed bool
 }
 
-// New returns a configured sarama Kafka-AsyncProducer instance
+// closeClient closes a client that was opened in New but can't be handed
+// off to a Producer because a later setup step failed, so the already-open
+// broker connections aren't leaked
+func closeClient(client sarama.Client, proxyLogger logger.Logger) {
+	if err := client.Close(); err != nil {
+		proxyLogger.Error("Error closing client after setup failure.", err)
+	}
+}
+
+// New returns a configured sarama Kafka-Producer instance, using either
+// the Async or Sync adapter depending on Config.Mode. A sarama.Client is
+// built first and retained, so liveness can later be checked via
+// IsActive/HealthCheck without opening extra connections
 func New(initConfig *Config) (*Producer, error) {
 	if initConfig.KafkaBrokers == nil || len(initConfig.KafkaBrokers) == 0 {
 		return nil, proxyerror.BrokersNotSetError("No Kafka Brokers set.")
@@ -51,24 +129,75 @@ func New(initConfig *Config) (*Producer, error) {
 		config.Producer.Compression = sarama.CompressionNone
 	}
 
-	producer, err := sarama.NewAsyncProducer(initConfig.KafkaBrokers, config)
+	if err := applyTLSConfig(config, initConfig); err != nil {
+		return nil, err
+	}
+	applySASLConfig(config, initConfig)
+	applyBatchingConfig(config, initConfig)
+
+	if initConfig.Mode == ModeSync {
+		// sarama.NewSyncProducer requires both of these to be enabled
+		config.Producer.Return.Errors = true
+		config.Producer.Return.Successes = true
+	}
+
+	var proxyLogger logger.Logger = logger.NoopLogger{}
+	if initConfig.Logger != nil {
+		proxyLogger = initConfig.Logger
+	}
+
+	client, err := sarama.NewClient(initConfig.KafkaBrokers, config)
 	if err != nil {
 		return nil, proxyerror.ConnectionError(err.Error())
 	}
 
+	if initConfig.Mode == ModeSync {
+		syncProducer, err := sarama.NewSyncProducerFromClient(client)
+		if err != nil {
+			closeClient(client, proxyLogger)
+			return nil, proxyerror.ConnectionError(err.Error())
+		}
+
+		proxyProducer := Producer{
+			client:       client,
+			syncProducer: syncProducer,
+			mode:         ModeSync,
+			logger:       proxyLogger,
+		}
+		proxyProducer.handleKeyInterrupt()
+		return &proxyProducer, nil
+	}
+
+	producer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		closeClient(client, proxyLogger)
+		return nil, proxyerror.ConnectionError(err.Error())
+	}
+
 	proxyProducer := Producer{
-		producer:         producer,
-		isClosed:         false,
-		isLoggingEnabled: false,
+		client:   client,
+		producer: producer,
+		mode:     ModeAsync,
+		logger:   proxyLogger,
 	}
 	proxyProducer.handleKeyInterrupt()
 	proxyProducer.handleErrors(initConfig.ErrHandler)
+	if config.Producer.Return.Successes {
+		// Only create the Successes() channel when nothing else is
+		// draining acks, since an unbuffered channel with no reader
+		// would otherwise wedge this goroutine (and eventually Input())
+		// forever, per sarama's "you MUST read from this channel" contract
+		if initConfig.SuccessHandler == nil {
+			proxyProducer.successesChan = make(chan *sarama.ProducerMessage)
+		}
+		proxyProducer.handleSuccesses(initConfig.SuccessHandler)
+	}
 	return &proxyProducer, nil
 }
 
 // EnableLogging logs events to console
 func (p *Producer) EnableLogging() {
-	p.isLoggingEnabled = true
+	p.logger = logger.StdLogger{}
 }
 
 // CreateKeyMessage creates producer-formatted message with key
@@ -92,16 +221,62 @@ func (p *Producer) CreateMessage(topic string, value string) *sarama.ProducerMes
 
 // IsClosed returns a bool specifying if Kafka producer is closed
 func (p *Producer) IsClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.isClosed
 }
 
-// Get returns the original Sarama Kafka producer
+// Get returns the original Sarama Kafka AsyncProducer. Only valid in
+// ModeAsync; returns nil in ModeSync, where there is no Adapter to get
 func (p *Producer) Get() *Adapter {
+	if p.mode != ModeAsync {
+		return nil
+	}
 	return &p.producer
 }
 
-// Input takes Kafka messages to be produced
+// IsActive checks whether the underlying client still knows of any live
+// brokers, without dialing a raw TCP connection
+func (p *Producer) IsActive() (bool, error) {
+	if len(p.client.Brokers()) > 0 {
+		return true, nil
+	}
+
+	return false, proxyerror.ConnectionError("No active Kafka brokers found.")
+}
+
+// HealthCheck periodically calls IsActive and reports unhealthy results
+// on the returned channel. The returned channel is closed once the
+// Producer is closed
+func (p *Producer) HealthCheck(interval time.Duration) <-chan error {
+	healthChan := make(chan error, 1)
+
+	go func() {
+		defer close(healthChan)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if p.IsClosed() {
+				return
+			}
+			if _, err := p.IsActive(); err != nil {
+				p.logger.Warn("Health-check failed.", err)
+				healthChan <- err
+			}
+		}
+	}()
+
+	return healthChan
+}
+
+// Input takes Kafka messages to be produced. Only valid in ModeAsync
 func (p *Producer) Input() (chan<- *sarama.ProducerMessage, error) {
+	if p.mode != ModeAsync {
+		return nil, proxyerror.ModeMismatchError("Input is only available for an async Producer.")
+	}
+
 	if !p.IsClosed() {
 		return p.producer.Input(), nil
 	}
@@ -110,6 +285,34 @@ func (p *Producer) Input() (chan<- *sarama.ProducerMessage, error) {
 	return nil, err
 }
 
+// SendMessage synchronously sends a single message and returns its
+// resulting partition and offset. Only valid in ModeSync
+func (p *Producer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if p.mode != ModeSync {
+		return 0, 0, proxyerror.ModeMismatchError("SendMessage is only available for a sync Producer.")
+	}
+
+	if p.IsClosed() {
+		return 0, 0, proxyerror.ResourceClosedError("Producer already closed.")
+	}
+
+	return p.syncProducer.SendMessage(msg)
+}
+
+// SendMessages synchronously sends a batch of messages. Only valid in
+// ModeSync
+func (p *Producer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	if p.mode != ModeSync {
+		return proxyerror.ModeMismatchError("SendMessages is only available for a sync Producer.")
+	}
+
+	if p.IsClosed() {
+		return proxyerror.ResourceClosedError("Producer already closed.")
+	}
+
+	return p.syncProducer.SendMessages(msgs)
+}
+
 func (p *Producer) handleKeyInterrupt() {
 	// Capture the Ctrl+C signal (interrupt or kill)
 	sigChan := make(chan os.Signal, 1)
@@ -122,9 +325,10 @@ func (p *Producer) handleKeyInterrupt() {
 	go func() {
 		<-sigChan
 		// We always log here, special situation
-		log.Println("Keyboard-Interrupt signal received.")
-		closeError := <-p.Close()
-		log.Fatalln(closeError.Error())
+		p.logger.Info("Keyboard-Interrupt signal received. Shutting down producer.")
+		if err := <-p.Close(); err != nil {
+			p.logger.Error("Error closing producer on interrupt.", err)
+		}
 	}()
 }
 
@@ -132,39 +336,75 @@ func (p *Producer) handleErrors(errHandler func(*sarama.ProducerError)) {
 	producer := *p.Get()
 	go func() {
 		for err := range producer.Errors() {
-			if p.isLoggingEnabled {
-				log.Fatalln("Failed to produce message", err)
+			p.logger.Error("Failed to produce message.", err)
+			if errHandler != nil {
+				errHandler(err)
 			}
-			errHandler(err)
 		}
 	}()
 }
 
-// Close attempts to close the producer,
-// and returns any occurring errors over channel
-func (p *Producer) Close() chan error {
-	// The error-channel only contains errors occurred
-	// while closing producer. Ignore if producer already
-	// closed.
-	if p.IsClosed() {
-		return nil
-	}
+// Successes returns the channel of acknowledged messages. Only populated
+// for an async Producer created with SaramaConfig.Producer.Return.Successes
+// enabled and no Config.SuccessHandler configured; nil otherwise
+func (p *Producer) Successes() <-chan *sarama.ProducerMessage {
+	return p.successesChan
+}
 
-	closeErrorChan := make(chan error, 1)
+func (p *Producer) handleSuccesses(successHandler func(*sarama.ProducerMessage)) {
+	producer := *p.Get()
 	go func() {
-		producer := *p.Get()
-		err := producer.Close()
-		if err != nil {
-			if p.isLoggingEnabled {
-				log.Fatal("Error closing async producer.", err)
+		for msg := range producer.Successes() {
+			// Forward to exactly one consumer: the handler if configured,
+			// otherwise the channel. Feeding both would still risk a
+			// blocked/forgotten channel read wedging this loop
+			if successHandler != nil {
+				successHandler(msg)
+				continue
 			}
-			closeErrorChan <- err
+			p.successesChan <- msg
 		}
-		if p.isLoggingEnabled {
-			log.Println("Async Producer closed.")
-		}
-		p.isClosed = true
 	}()
+}
+
+// Close attempts to close the producer, and returns a buffered
+// error-channel carrying any errors that occurred while closing.
+// The channel is always closed once shutdown completes. Close is
+// idempotent: repeated calls return the same channel
+func (p *Producer) Close() chan error {
+	p.closeOnce.Do(func() {
+		p.closeErrChan = make(chan error, 2)
+		go func() {
+			defer close(p.closeErrChan)
+
+			var err error
+			if p.mode == ModeSync {
+				err = p.syncProducer.Close()
+			} else {
+				producer := *p.Get()
+				err = producer.Close()
+			}
+			if err != nil {
+				p.logger.Error("Error closing producer.", err)
+				p.closeErrChan <- err
+			}
+
+			// Closing the producer above also closes the underlying client's
+			// connections for AsyncProducer/SyncProducer, but the client
+			// itself must be closed separately to release its resources
+			if !p.client.Closed() {
+				if err := p.client.Close(); err != nil {
+					p.logger.Error("Error closing client.", err)
+					p.closeErrChan <- err
+				}
+			}
+
+			p.logger.Info("Producer closed.")
+			p.mu.Lock()
+			p.isClosed = true
+			p.mu.Unlock()
+		}()
+	})
 
-	return closeErrorChan
+	return p.closeErrChan
 }
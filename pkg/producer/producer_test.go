@@ -0,0 +1,298 @@
+package producer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/logger"
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/proxyerror"
+
+	"github.com/Shopify/sarama"
+)
+
+// fakeAdapter is a minimal Adapter stub for exercising Producer's
+// shutdown/concurrency behavior without a real broker. Embedding the
+// interface satisfies it; only the methods under test are overridden
+type fakeAdapter struct {
+	Adapter
+
+	successesChan chan *sarama.ProducerMessage
+}
+
+func (f *fakeAdapter) Close() error {
+	return nil
+}
+
+func (f *fakeAdapter) Input() chan<- *sarama.ProducerMessage {
+	return make(chan *sarama.ProducerMessage, 1)
+}
+
+func (f *fakeAdapter) Successes() <-chan *sarama.ProducerMessage {
+	return f.successesChan
+}
+
+// fakeSyncProducer is a minimal sarama.SyncProducer stub for exercising
+// Producer's ModeSync send-path without a real broker
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+
+	partition int32
+	offset    int64
+	sentBatch []*sarama.ProducerMessage
+}
+
+func (f *fakeSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	return f.partition, f.offset, nil
+}
+
+func (f *fakeSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	f.sentBatch = msgs
+	return nil
+}
+
+// fakeClient is a minimal sarama.Client stub, same embedding trick as
+// fakeAdapter
+type fakeClient struct {
+	sarama.Client
+
+	mu      sync.Mutex
+	closed  bool
+	brokers []*sarama.Broker
+}
+
+func (f *fakeClient) Brokers() []*sarama.Broker {
+	return f.brokers
+}
+
+func (f *fakeClient) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *fakeClient) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	return nil
+}
+
+// TestProducerConcurrentCloseIsRaceFreeAndIdempotent exercises Close,
+// IsClosed and Input concurrently under `go test -race` to guard the
+// isClosed-mutex and idempotent-Close fix
+func TestProducerConcurrentCloseIsRaceFreeAndIdempotent(t *testing.T) {
+	p := &Producer{
+		producer: &fakeAdapter{},
+		client:   &fakeClient{},
+		mode:     ModeAsync,
+		logger:   logger.NoopLogger{},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			<-p.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			p.IsClosed()
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = p.Input()
+		}()
+	}
+	wg.Wait()
+
+	if !p.IsClosed() {
+		t.Fatal("expected producer to report closed after concurrent Close calls")
+	}
+
+	closeErrChan := p.Close()
+	if _, ok := <-closeErrChan; ok {
+		t.Fatal("expected repeated Close() to return an already-closed channel")
+	}
+}
+
+func TestSendMessageRoutesToSyncProducer(t *testing.T) {
+	p := &Producer{
+		mode:         ModeSync,
+		syncProducer: &fakeSyncProducer{partition: 3, offset: 42},
+		logger:       logger.NoopLogger{},
+	}
+
+	partition, offset, err := p.SendMessage(&sarama.ProducerMessage{Topic: "t"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partition != 3 || offset != 42 {
+		t.Fatalf("expected partition=3 offset=42, got partition=%d offset=%d", partition, offset)
+	}
+}
+
+func TestSendMessageRejectsAsyncMode(t *testing.T) {
+	p := &Producer{mode: ModeAsync, logger: logger.NoopLogger{}}
+
+	_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "t"})
+	proxyErr, ok := err.(*proxyerror.ProxyError)
+	if !ok {
+		t.Fatalf("expected a *proxyerror.ProxyError, got %T", err)
+	}
+	if proxyErr.Code != 7 {
+		t.Fatalf("expected a ModeMismatchError (code 7), got code %d", proxyErr.Code)
+	}
+}
+
+func TestSendMessageRejectsClosedProducer(t *testing.T) {
+	p := &Producer{mode: ModeSync, syncProducer: &fakeSyncProducer{}, logger: logger.NoopLogger{}}
+	p.isClosed = true
+
+	_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "t"})
+	proxyErr, ok := err.(*proxyerror.ProxyError)
+	if !ok {
+		t.Fatalf("expected a *proxyerror.ProxyError, got %T", err)
+	}
+	if proxyErr.Code != 4 {
+		t.Fatalf("expected a ResourceClosedError (code 4), got code %d", proxyErr.Code)
+	}
+}
+
+func TestSendMessagesRoutesToSyncProducer(t *testing.T) {
+	syncProducer := &fakeSyncProducer{}
+	p := &Producer{mode: ModeSync, syncProducer: syncProducer, logger: logger.NoopLogger{}}
+
+	msgs := []*sarama.ProducerMessage{{Topic: "a"}, {Topic: "b"}}
+	if err := p.SendMessages(msgs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(syncProducer.sentBatch) != 2 {
+		t.Fatalf("expected both messages to reach the sync producer, got %d", len(syncProducer.sentBatch))
+	}
+}
+
+func TestSendMessagesRejectsAsyncMode(t *testing.T) {
+	p := &Producer{mode: ModeAsync, logger: logger.NoopLogger{}}
+
+	err := p.SendMessages([]*sarama.ProducerMessage{{Topic: "t"}})
+	proxyErr, ok := err.(*proxyerror.ProxyError)
+	if !ok {
+		t.Fatalf("expected a *proxyerror.ProxyError, got %T", err)
+	}
+	if proxyErr.Code != 7 {
+		t.Fatalf("expected a ModeMismatchError (code 7), got code %d", proxyErr.Code)
+	}
+}
+
+func TestIsActiveReportsTrueWhenBrokersPresent(t *testing.T) {
+	p := &Producer{client: &fakeClient{brokers: []*sarama.Broker{sarama.NewBroker("localhost:9092")}}}
+
+	active, err := p.IsActive()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !active {
+		t.Fatal("expected IsActive to report true when brokers are present")
+	}
+}
+
+func TestIsActiveReportsFalseWhenNoBrokers(t *testing.T) {
+	p := &Producer{client: &fakeClient{}}
+
+	active, err := p.IsActive()
+	if active {
+		t.Fatal("expected IsActive to report false when no brokers are present")
+	}
+	if err == nil {
+		t.Fatal("expected an error when no brokers are present")
+	}
+}
+
+func TestHealthCheckReportsErrorsUntilClosed(t *testing.T) {
+	p := &Producer{
+		client:   &fakeClient{},
+		producer: &fakeAdapter{},
+		mode:     ModeAsync,
+		logger:   logger.NoopLogger{},
+	}
+
+	healthChan := p.HealthCheck(5 * time.Millisecond)
+
+	sawError := false
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case err, ok := <-healthChan:
+			if !ok {
+				if !sawError {
+					t.Fatal("expected at least one health-check error before the channel closed")
+				}
+				return
+			}
+			if err != nil && !sawError {
+				sawError = true
+				<-p.Close()
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for health-check channel to close")
+		}
+	}
+}
+
+func TestHandleSuccessesWithHandlerDoesNotPopulateChannel(t *testing.T) {
+	rawSuccesses := make(chan *sarama.ProducerMessage, 1)
+	p := &Producer{
+		producer: &fakeAdapter{successesChan: rawSuccesses},
+		mode:     ModeAsync,
+		logger:   logger.NoopLogger{},
+	}
+
+	received := make(chan *sarama.ProducerMessage, 1)
+	p.handleSuccesses(func(msg *sarama.ProducerMessage) {
+		received <- msg
+	})
+
+	msg := &sarama.ProducerMessage{Topic: "t"}
+	rawSuccesses <- msg
+
+	select {
+	case got := <-received:
+		if got != msg {
+			t.Fatalf("expected handler to receive %v, got %v", msg, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for SuccessHandler to be invoked")
+	}
+
+	// p.successesChan was never allocated (mirrors New(), which only
+	// allocates it when SuccessHandler is nil), so Successes() must be nil
+	if p.Successes() != nil {
+		t.Fatal("expected Successes() to be nil when SuccessHandler is set")
+	}
+}
+
+func TestHandleSuccessesWithoutHandlerForwardsToChannel(t *testing.T) {
+	rawSuccesses := make(chan *sarama.ProducerMessage, 1)
+	p := &Producer{
+		producer:      &fakeAdapter{successesChan: rawSuccesses},
+		mode:          ModeAsync,
+		successesChan: make(chan *sarama.ProducerMessage, 1),
+		logger:        logger.NoopLogger{},
+	}
+
+	p.handleSuccesses(nil)
+
+	msg := &sarama.ProducerMessage{Topic: "t"}
+	rawSuccesses <- msg
+
+	select {
+	case got := <-p.Successes():
+		if got != msg {
+			t.Fatalf("expected Successes() to receive %v, got %v", msg, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Successes() to receive the message")
+	}
+}
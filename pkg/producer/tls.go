@@ -0,0 +1,73 @@
+package producer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/Jaskaranbir/go-kafkaproxy/pkg/proxyerror"
+
+	"github.com/Shopify/sarama"
+)
+
+// buildTLSConfig loads the configured certificate/key pair and CA, and
+// returns a tls.Config ready to be plugged into sarama's Net.TLS.Config
+func buildTLSConfig(initConfig *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: initConfig.TLSInsecureSkipVerify,
+	}
+
+	if initConfig.TLSCertFile != "" && initConfig.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(initConfig.TLSCertFile, initConfig.TLSKeyFile)
+		if err != nil {
+			return nil, proxyerror.TLSConfigError("Unable to load TLS cert/key pair.", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if initConfig.TLSCAFile != "" {
+		caCert, err := ioutil.ReadFile(initConfig.TLSCAFile)
+		if err != nil {
+			return nil, proxyerror.TLSConfigError("Unable to read TLS CA file.", err.Error())
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, proxyerror.TLSConfigError("Unable to parse TLS CA file.")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	return tlsConfig, nil
+}
+
+// applyTLSConfig enables TLS on the sarama-config if requested
+func applyTLSConfig(config *sarama.Config, initConfig *Config) error {
+	if !initConfig.TLSEnable {
+		return nil
+	}
+
+	tlsConfig, err := buildTLSConfig(initConfig)
+	if err != nil {
+		return err
+	}
+
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+	return nil
+}
+
+// applySASLConfig enables SASL authentication on the sarama-config if
+// credentials are provided
+func applySASLConfig(config *sarama.Config, initConfig *Config) {
+	if !initConfig.SASLEnable {
+		return
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.User = initConfig.SASLUser
+	config.Net.SASL.Password = initConfig.SASLPassword
+	if initConfig.SASLMechanism != "" {
+		config.Net.SASL.Mechanism = initConfig.SASLMechanism
+	}
+}
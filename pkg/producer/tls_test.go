@@ -0,0 +1,164 @@
+package producer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// genSelfSignedCert returns PEM-encoded bytes for a throwaway self-signed
+// certificate/key pair, good enough to exercise tls.LoadX509KeyPair and
+// x509.CertPool parsing without hitting the network
+func genSelfSignedCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-kafkaproxy-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func writeTempFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestBuildTLSConfigLoadsCertKeyAndCA(t *testing.T) {
+	certPEM, keyPEM := genSelfSignedCert(t)
+	dir := t.TempDir()
+	certPath := writeTempFile(t, dir, "cert.pem", certPEM)
+	keyPath := writeTempFile(t, dir, "key.pem", keyPEM)
+	caPath := writeTempFile(t, dir, "ca.pem", certPEM)
+
+	tlsConfig, err := buildTLSConfig(&Config{
+		TLSCertFile:           certPath,
+		TLSKeyFile:            keyPath,
+		TLSCAFile:             caPath,
+		TLSInsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("buildTLSConfig returned error: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected 1 loaded certificate, got %d", len(tlsConfig.Certificates))
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Fatal("expected RootCAs to be populated from TLSCAFile")
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Fatal("expected InsecureSkipVerify to be carried through")
+	}
+}
+
+func TestBuildTLSConfigMissingCertFile(t *testing.T) {
+	dir := t.TempDir()
+	_, err := buildTLSConfig(&Config{
+		TLSCertFile: filepath.Join(dir, "missing-cert.pem"),
+		TLSKeyFile:  filepath.Join(dir, "missing-key.pem"),
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing cert/key pair")
+	}
+}
+
+func TestBuildTLSConfigBadCAFile(t *testing.T) {
+	dir := t.TempDir()
+	caPath := writeTempFile(t, dir, "ca.pem", []byte("not a valid PEM certificate"))
+
+	_, err := buildTLSConfig(&Config{TLSCAFile: caPath})
+	if err == nil {
+		t.Fatal("expected an error for an unparsable CA file")
+	}
+}
+
+func TestApplyTLSConfigDisabledIsNoop(t *testing.T) {
+	config := sarama.NewConfig()
+	if err := applyTLSConfig(config, &Config{TLSEnable: false}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Net.TLS.Enable {
+		t.Fatal("expected TLS to remain disabled when TLSEnable is false")
+	}
+}
+
+func TestApplyTLSConfigEnabled(t *testing.T) {
+	certPEM, keyPEM := genSelfSignedCert(t)
+	dir := t.TempDir()
+	certPath := writeTempFile(t, dir, "cert.pem", certPEM)
+	keyPath := writeTempFile(t, dir, "key.pem", keyPEM)
+
+	config := sarama.NewConfig()
+	err := applyTLSConfig(config, &Config{
+		TLSEnable:   true,
+		TLSCertFile: certPath,
+		TLSKeyFile:  keyPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Net.TLS.Enable {
+		t.Fatal("expected TLS to be enabled")
+	}
+	if config.Net.TLS.Config == nil {
+		t.Fatal("expected Net.TLS.Config to be populated")
+	}
+}
+
+func TestApplySASLConfig(t *testing.T) {
+	config := sarama.NewConfig()
+	applySASLConfig(config, &Config{
+		SASLEnable:    true,
+		SASLMechanism: sarama.SASLTypeSCRAMSHA256,
+		SASLUser:      "user",
+		SASLPassword:  "pass",
+	})
+
+	if !config.Net.SASL.Enable {
+		t.Fatal("expected SASL to be enabled")
+	}
+	if config.Net.SASL.User != "user" || config.Net.SASL.Password != "pass" {
+		t.Fatalf("unexpected SASL credentials: %+v", config.Net.SASL)
+	}
+	if config.Net.SASL.Mechanism != sarama.SASLTypeSCRAMSHA256 {
+		t.Fatalf("expected SASL mechanism to be overridden, got %q", config.Net.SASL.Mechanism)
+	}
+}
+
+func TestApplySASLConfigDisabledIsNoop(t *testing.T) {
+	config := sarama.NewConfig()
+	applySASLConfig(config, &Config{SASLEnable: false})
+	if config.Net.SASL.Enable {
+		t.Fatal("expected SASL to remain disabled when SASLEnable is false")
+	}
+}
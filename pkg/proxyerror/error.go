@@ -60,3 +60,16 @@ func ResourceWriteError(messages ...string) *ProxyError {
 	proxyError := createError(5, messages)
 	return proxyError
 }
+
+// TLSConfigError represents error while loading TLS certificates/keys
+func TLSConfigError(messages ...string) *ProxyError {
+	proxyError := createError(6, messages)
+	return proxyError
+}
+
+// ModeMismatchError represents error when an operation is invoked against
+// a producer/consumer that is not configured for that mode (sync/async)
+func ModeMismatchError(messages ...string) *ProxyError {
+	proxyError := createError(7, messages)
+	return proxyError
+}